@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChaosMode selects how the ChaosInjector disrupts a target alpha.
+type ChaosMode string
+
+const (
+	ChaosModePause      ChaosMode = "pause"       // SIGSTOP/SIGCONT the alpha container.
+	ChaosModeRemoveNode ChaosMode = "remove-node" // Drop the alpha from its raft group via Zero, then restart it to rejoin.
+	ChaosModePartition  ChaosMode = "partition"   // Introduce packet loss via an external iptables/tc helper.
+)
+
+// ChaosTarget names one alpha the injector can act against.
+type ChaosTarget struct {
+	Container string // Docker container name, used by all modes (remove-node restarts it to rejoin).
+	GroupID   uint32 // Raft group id, used by remove-node mode.
+	NodeID    uint64 // Raft node id, used by remove-node mode.
+}
+
+// ChaosInjector periodically disrupts a random target alpha to reproduce
+// raft-related write-loss scenarios, turning the load test from a passive
+// generator into a targeted reproducer.
+type ChaosInjector struct {
+	mu sync.RWMutex
+
+	targets         []ChaosTarget
+	modes           []ChaosMode
+	zeroAddr        string
+	partitionScript string
+	httpClient      *http.Client
+
+	currentState string // e.g. "pause:alpha2"; empty when no chaos is active.
+}
+
+// NewChaosInjector builds an injector over targets, choosing among modes at
+// random.
+// partitionScript is the path to an optional shell helper wrapping
+// iptables/tc for the partition mode; it is invoked as
+// `partitionScript <container> <add|del>`.
+func NewChaosInjector(targets []ChaosTarget, modes []ChaosMode, zeroAddr string, partitionScript string) *ChaosInjector {
+	return &ChaosInjector{
+		targets:         targets,
+		modes:           modes,
+		zeroAddr:        zeroAddr,
+		partitionScript: partitionScript,
+		httpClient:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// CurrentState returns the chaos action presently active, or "" if none.
+// Safe for concurrent use; callers annotate journal entries with this so
+// validation results can be correlated with the specific failure injected.
+func (c *ChaosInjector) CurrentState() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.currentState
+}
+
+func (c *ChaosInjector) setState(state string) {
+	c.mu.Lock()
+	c.currentState = state
+	c.mu.Unlock()
+}
+
+// Run picks a random target and mode every interval, applies it for
+// duration, then reverts it. It stops when ctx is cancelled.
+func (c *ChaosInjector) Run(ctx context.Context, interval, duration time.Duration) {
+	if len(c.targets) == 0 || len(c.modes) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			target := c.targets[rand.Intn(len(c.targets))]
+			mode := c.modes[rand.Intn(len(c.modes))]
+			c.inject(ctx, target, mode, duration)
+		}
+	}
+}
+
+// inject applies mode against target, holds it for duration, then reverts
+// it, updating currentState and chaosActive throughout so the rest of the
+// tool can correlate write outcomes with the active fault.
+func (c *ChaosInjector) inject(ctx context.Context, target ChaosTarget, mode ChaosMode, duration time.Duration) {
+	state := fmt.Sprintf("%s:%s", mode, target.Container)
+	fmt.Printf("%s: chaos injecting %s\n", time.Now().Format(time.RFC3339), state)
+
+	if err := c.apply(ctx, target, mode); err != nil {
+		fmt.Printf("chaos: failed to apply %s: %v\n", state, err)
+		return
+	}
+
+	c.setState(state)
+	chaosActive.WithLabelValues(target.Container, string(mode)).Set(1)
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(duration):
+	}
+
+	if err := c.revert(ctx, target, mode); err != nil {
+		fmt.Printf("chaos: failed to revert %s: %v\n", state, err)
+	}
+
+	chaosActive.WithLabelValues(target.Container, string(mode)).Set(0)
+	c.setState("")
+	fmt.Printf("%s: chaos reverted %s\n", time.Now().Format(time.RFC3339), state)
+}
+
+func (c *ChaosInjector) apply(ctx context.Context, target ChaosTarget, mode ChaosMode) error {
+	switch mode {
+	case ChaosModePause:
+		return dockerKill(ctx, target.Container, "SIGSTOP")
+	case ChaosModeRemoveNode:
+		return c.removeNode(ctx, target)
+	case ChaosModePartition:
+		return c.runPartitionScript(ctx, target.Container, "add")
+	default:
+		return fmt.Errorf("unknown chaos mode %q", mode)
+	}
+}
+
+func (c *ChaosInjector) revert(ctx context.Context, target ChaosTarget, mode ChaosMode) error {
+	switch mode {
+	case ChaosModePause:
+		return dockerKill(ctx, target.Container, "SIGCONT")
+	case ChaosModeRemoveNode:
+		// Zero doesn't support re-adding a removed node directly; restarting
+		// the alpha container makes it rejoin its group on its own.
+		return dockerRestart(ctx, target.Container)
+	case ChaosModePartition:
+		return c.runPartitionScript(ctx, target.Container, "del")
+	default:
+		return fmt.Errorf("unknown chaos mode %q", mode)
+	}
+}
+
+// removeNode drops target from its raft group via Zero's /removeNode
+// endpoint.
+func (c *ChaosInjector) removeNode(ctx context.Context, target ChaosTarget) error {
+	url := fmt.Sprintf("%s/removeNode?group=%d&id=%d", c.zeroAddr, target.GroupID, target.NodeID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("removeNode returned %s", resp.Status)
+	}
+
+	return nil
+}
+
+// runPartitionScript shells out to the optional user-supplied helper that
+// wraps iptables/tc to introduce packet loss between the writer and
+// container. action is "add" or "del".
+func (c *ChaosInjector) runPartitionScript(ctx context.Context, container string, action string) error {
+	if c.partitionScript == "" {
+		return fmt.Errorf("no -chaos-partition-script configured")
+	}
+
+	cmd := exec.CommandContext(ctx, c.partitionScript, container, action)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s %s: %w (%s)", c.partitionScript, container, action, err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+func dockerKill(ctx context.Context, container string, signal string) error {
+	cmd := exec.CommandContext(ctx, "docker", "kill", "--signal="+signal, container)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("docker kill --signal=%s %s: %w (%s)", signal, container, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func dockerRestart(ctx context.Context, container string) error {
+	cmd := exec.CommandContext(ctx, "docker", "restart", container)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("docker restart %s: %w (%s)", container, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// parseChaosTargets builds ChaosTargets from the -chaos-containers and
+// -chaos-node-ids flags. nodeIDs is optional (only required for the
+// remove-node mode) but when present must have one group:id pair per
+// container, in the same order.
+func parseChaosTargets(containers string, nodeIDs string) ([]ChaosTarget, error) {
+	containers = strings.TrimSpace(containers)
+	if containers == "" {
+		return nil, nil
+	}
+
+	containerNames := strings.Split(containers, ",")
+
+	var groupIDs []uint32
+	var nodeIDList []uint64
+	if nodeIDs = strings.TrimSpace(nodeIDs); nodeIDs != "" {
+		pairs := strings.Split(nodeIDs, ",")
+		if len(pairs) != len(containerNames) {
+			return nil, fmt.Errorf("-chaos-node-ids must have one group:id pair per -chaos-containers entry")
+		}
+		for _, pair := range pairs {
+			group, id, err := parseGroupNodeID(pair)
+			if err != nil {
+				return nil, err
+			}
+			groupIDs = append(groupIDs, group)
+			nodeIDList = append(nodeIDList, id)
+		}
+	}
+
+	targets := make([]ChaosTarget, len(containerNames))
+	for i, name := range containerNames {
+		target := ChaosTarget{Container: strings.TrimSpace(name)}
+		if groupIDs != nil {
+			target.GroupID = groupIDs[i]
+			target.NodeID = nodeIDList[i]
+		}
+		targets[i] = target
+	}
+
+	return targets, nil
+}
+
+func parseGroupNodeID(pair string) (uint32, uint64, error) {
+	parts := strings.SplitN(pair, ":", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid group:id pair %q", pair)
+	}
+
+	var group uint32
+	var id uint64
+	if _, err := fmt.Sscanf(parts[0], "%d", &group); err != nil {
+		return 0, 0, fmt.Errorf("invalid group in %q: %w", pair, err)
+	}
+	if _, err := fmt.Sscanf(parts[1], "%d", &id); err != nil {
+		return 0, 0, fmt.Errorf("invalid id in %q: %w", pair, err)
+	}
+
+	return group, id, nil
+}
+
+// parseChaosModes parses the comma-separated -chaos-modes flag.
+func parseChaosModes(modes string) []ChaosMode {
+	var result []ChaosMode
+	for _, m := range strings.Split(modes, ",") {
+		m = strings.TrimSpace(m)
+		if m != "" {
+			result = append(result, ChaosMode(m))
+		}
+	}
+	return result
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/RoaringBitmap/roaring"
+	"github.com/hasura/go-graphql-client"
+)
+
+// pendingValue is an event value the GapDetector expects to see in a poll
+// window before its grace period elapses.
+type pendingValue struct {
+	value     uint64
+	submitted time.Time
+	deadline  time.Time
+}
+
+// pendingHeap is a min-heap of pendingValues ordered by deadline, so expired
+// entries can be popped cheaply.
+type pendingHeap []*pendingValue
+
+func (h pendingHeap) Len() int            { return len(h) }
+func (h pendingHeap) Less(i, j int) bool  { return h[i].deadline.Before(h[j].deadline) }
+func (h pendingHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *pendingHeap) Push(x interface{}) { *h = append(*h, x.(*pendingValue)) }
+func (h *pendingHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// GapDetector streams a small window of recently-written events per server
+// via queryEventWindow and flags any expected value that hasn't shown up
+// within a grace period, so a gap is caught within seconds instead of at the
+// next paginated validation checkpoint.
+type GapDetector struct {
+	mu          sync.Mutex
+	seen        map[string]*roaring.Bitmap
+	pending     map[string]*pendingHeap
+	watermark   map[string]uint32
+	gracePeriod time.Duration
+	errLog      *os.File
+}
+
+// NewGapDetector creates a detector for the given servers. gracePeriod is
+// how long a submitted value is given to appear in the poll window before
+// it's reported as a gap.
+func NewGapDetector(servers []string, gracePeriod time.Duration, errLog *os.File) *GapDetector {
+	d := &GapDetector{
+		seen:        make(map[string]*roaring.Bitmap, len(servers)),
+		pending:     make(map[string]*pendingHeap, len(servers)),
+		watermark:   make(map[string]uint32, len(servers)),
+		gracePeriod: gracePeriod,
+		errLog:      errLog,
+	}
+
+	for _, server := range servers {
+		d.seen[server] = roaring.New()
+		h := &pendingHeap{}
+		heap.Init(h)
+		d.pending[server] = h
+	}
+
+	return d
+}
+
+// Expect registers a value the writer just confirmed was submitted to
+// server, to be observed in the poll window within the grace period.
+func (d *GapDetector) Expect(server string, value uint64, submittedAt time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	heap.Push(d.pending[server], &pendingValue{
+		value:     value,
+		submitted: submittedAt,
+		deadline:  submittedAt.Add(d.gracePeriod),
+	})
+}
+
+// Run polls every server's recent window on pollInterval until ctx is
+// cancelled.
+func (d *GapDetector) Run(ctx context.Context, clients []*graphql.Client, servers []string, pollInterval time.Duration) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for i, client := range clients {
+				d.pollServer(ctx, client, servers[i])
+			}
+		}
+	}
+}
+
+// pollServer fetches events at or beyond the current watermark, marks them
+// seen, and reports any pending values whose grace period has expired.
+func (d *GapDetector) pollServer(ctx context.Context, client *graphql.Client, server string) {
+	d.mu.Lock()
+	watermark := d.watermark[server]
+	d.mu.Unlock()
+
+	values, err := queryEventWindow(ctx, client, watermark, 5000)
+	if err != nil {
+		fmt.Printf("gap detector: failed to poll %s: %v\n", server, err)
+		return
+	}
+
+	if len(values) > 0 {
+		d.mu.Lock()
+		bitmap := d.seen[server]
+		for _, v := range values {
+			bitmap.Add(uint32(v))
+			if uint32(v) >= d.watermark[server] {
+				d.watermark[server] = uint32(v) + 1
+			}
+		}
+		// Concurrent workers commit out of order, so a value can still be
+		// in flight when a later value is already seen. Clamp the
+		// watermark back to the smallest not-yet-seen pending value so it
+		// never runs ahead of a straggler and permanently excludes it from
+		// future polls.
+		if floor, ok := d.minUnseenPending(server); ok && floor < d.watermark[server] {
+			d.watermark[server] = floor
+		}
+		d.mu.Unlock()
+	}
+
+	d.reportExpired(server)
+}
+
+// minUnseenPending returns the smallest value among server's pending
+// entries that hasn't been observed in a poll yet. Caller holds d.mu.
+func (d *GapDetector) minUnseenPending(server string) (uint32, bool) {
+	bitmap := d.seen[server]
+	var floor uint32
+	found := false
+	for _, p := range *d.pending[server] {
+		if bitmap.Contains(uint32(p.value)) {
+			continue
+		}
+		if !found || uint32(p.value) < floor {
+			floor = uint32(p.value)
+			found = true
+		}
+	}
+	return floor, found
+}
+
+// reportExpired pops pending values whose deadline has passed, emitting a
+// gap_detected event for any that still haven't been seen.
+func (d *GapDetector) reportExpired(server string) {
+	now := time.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	h := d.pending[server]
+	bitmap := d.seen[server]
+
+	for h.Len() > 0 {
+		next := (*h)[0]
+		if next.deadline.After(now) {
+			return
+		}
+		heap.Pop(h)
+
+		if bitmap.Contains(uint32(next.value)) {
+			continue
+		}
+
+		gapDetectedTotal.WithLabelValues(server).Inc()
+		msg := fmt.Sprintf("%s: gap_detected value=%d server=%s inserted=%s (%s ago)\n",
+			now.Format(time.RFC3339), next.value, server, next.submitted.Format(time.RFC3339), now.Sub(next.submitted).Round(time.Second))
+		if _, err := d.errLog.WriteString(msg); err != nil {
+			fmt.Print(msg)
+		}
+	}
+}
+
+// queryEventWindow fetches up to `first` events with value >= watermark, in
+// ascending order, for the sliding-window gap detector.
+func queryEventWindow(ctx context.Context, client *graphql.Client, watermark uint32, first int) ([]uint64, error) {
+	var q struct {
+		QueryEvent []eventRow `graphql:"queryEvent(filter: {value: {ge: $watermark}}, first: $first, order: {asc: value})"`
+	}
+
+	variables := map[string]interface{}{
+		"watermark": watermark,
+		"first":     first,
+	}
+
+	bytes, err := client.QueryRaw(ctx, &q, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		QueryEvent []eventRow `json:"queryEvent"`
+	}
+	if err := json.Unmarshal(bytes, &result); err != nil {
+		return nil, err
+	}
+
+	values := make([]uint64, len(result.QueryEvent))
+	for i, e := range result.QueryEvent {
+		values[i] = e.Value
+	}
+
+	return values, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// JournalEntry is a single append-only record of an attempted event write.
+// It exists so a crash of the writer process doesn't lose all provenance for
+// diagnosing gaps: which server an event was written to, when, and whether
+// the mutation returned success.
+type JournalEntry struct {
+	Value       uint64    `json:"value"`
+	WorkerID    int       `json:"worker_id"`
+	Server      string    `json:"server"`
+	SubmittedAt time.Time `json:"submitted_at"`
+	RespondedAt time.Time `json:"responded_at"`
+	Status      string    `json:"status"`
+	// ChaosState is the chaos action active (against any target alpha, not
+	// necessarily this write's server) when the entry was recorded (empty
+	// when none), so validation results can be correlated with the specific
+	// failure injected.
+	ChaosState string `json:"chaos_state,omitempty"`
+}
+
+// Journal is an append-only, buffered log of pending event writes, flushed
+// and fsynced on a short interval so at most a second of writes is lost on a
+// crash.
+type Journal struct {
+	mu     sync.Mutex
+	file   *os.File
+	writer *bufio.Writer
+}
+
+// NewJournal opens (or creates) the journal file at path for appending and
+// starts a background flush/fsync loop.
+func NewJournal(path string) (*Journal, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &Journal{
+		file:   file,
+		writer: bufio.NewWriter(file),
+	}
+
+	go j.syncLoop()
+
+	return j, nil
+}
+
+func (j *Journal) syncLoop() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		j.mu.Lock()
+		if err := j.writer.Flush(); err != nil {
+			fmt.Printf("journal: error flushing: %v\n", err)
+		}
+		if err := j.file.Sync(); err != nil {
+			fmt.Printf("journal: error syncing: %v\n", err)
+		}
+		j.mu.Unlock()
+	}
+}
+
+// Append writes a single entry to the journal. It is safe for concurrent use.
+func (j *Journal) Append(entry JournalEntry) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		fmt.Printf("journal: error marshaling entry: %v\n", err)
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, err := j.writer.Write(line); err != nil {
+		fmt.Printf("journal: error writing entry: %v\n", err)
+		return
+	}
+	if _, err := j.writer.WriteString("\n"); err != nil {
+		fmt.Printf("journal: error writing entry: %v\n", err)
+	}
+}
+
+// Close flushes and fsyncs any buffered entries before closing the file.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := j.writer.Flush(); err != nil {
+		return err
+	}
+	if err := j.file.Sync(); err != nil {
+		return err
+	}
+	return j.file.Close()
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"math/big"
 	"net/http"
@@ -13,30 +14,63 @@ import (
 	"time"
 
 	"github.com/hasura/go-graphql-client"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 const (
-	WORKERS              = 5                      // Number of concurrent writers.
-	WORKER_LOOP_INTERVAL = 100 * time.Millisecond // Per-worker pacing between event inserts.
-	MAX_EVENTS           = 100000                 // Upper bound on events to write before stopping.
-	VALIDATION_INTERVAL  = 2500                   // Validate every N inserted events.
-	VALIDATION_POLL      = 2 * time.Second        // Polling interval for validation scheduling.
+	WORKERS             = 5              // Number of concurrent writers.
+	MAX_EVENTS          = 100000         // Upper bound on events to write before stopping.
+	VALIDATION_INTERVAL = 2500           // Validate every N inserted events.
+	VALIDATION_POLL     = 2 * time.Second // Polling interval for validation scheduling.
 )
 
 var (
-	count          uint64            = 0 // Monotonic event counter shared by workers.
-	mu                               = sync.Mutex{}
-	people                           = []string{"Alice", "Bob", "Charlie", "David", "Eve"} // Seed people IDs to link events to.
-	insertTimeMu                     = sync.Mutex{}
-	insertTime     map[int]time.Time // Tracks when each event value was submitted (for debugging gaps).
-	servers        = []string{"http://localhost:8080", "http://localhost:8081", "http://localhost:8082"}
-	missingCountMu = sync.Mutex{}
-	missingCount   = map[string]int{} // Tracks missing event counts per server for reporting.
+	rateLimiterKind = flag.String("rate-limiter", "aimd", "Rate limiting strategy per server: token-bucket or aimd")
+	targetOpsPerSec = flag.Float64("target-ops", 20, "Target ops/sec per server for the token-bucket limiter, or the starting rate for aimd")
+	journalPath     = flag.String("journal", "journal.log", "Path to the append-only write journal")
+	protocol        = flag.String("protocol", "graphql", "Write protocol to use: graphql, dql, or grpc")
+	gapGracePeriod  = flag.Duration("gap-grace-period", 15*time.Second, "How long a written value is given to appear before the gap detector reports it missing")
+	gapPollInterval = flag.Duration("gap-poll-interval", 1*time.Second, "How often the gap detector polls each server's recent event window")
+
+	chaosContainers      = flag.String("chaos-containers", "", "Comma-separated Docker container names of alphas to inject chaos against (empty disables chaos)")
+	chaosNodeIDs         = flag.String("chaos-node-ids", "", "Comma-separated group:node raft ids, aligned with -chaos-containers, for the remove-node mode")
+	chaosModes           = flag.String("chaos-modes", "pause", "Comma-separated chaos modes to choose from at random: pause, remove-node, partition")
+	chaosZero            = flag.String("chaos-zero", "http://localhost:6080", "Dgraph Zero address for the remove-node chaos mode")
+	chaosPartitionScript = flag.String("chaos-partition-script", "", "Path to an optional shell helper wrapping iptables/tc for the partition chaos mode")
+	chaosInterval        = flag.Duration("chaos-interval", time.Minute, "How often the chaos injector picks a new target to disrupt")
+	chaosDuration        = flag.Duration("chaos-duration", 15*time.Second, "How long each chaos action is held before reverting")
+
+	activeWriter  Writer // Writer implementation selected by -protocol.
+	gapDetector   *GapDetector
+	chaosInjector *ChaosInjector
+
+	count   uint64 = 0 // Monotonic event counter shared by workers.
+	mu             = sync.Mutex{}
+	people         = []string{"Alice", "Bob", "Charlie", "David", "Eve"} // Seed people IDs to link events to.
+	journal *Journal                                                    // Append-only record of attempted event writes, for gap forensics.
+	servers = []string{"http://localhost:8080", "http://localhost:8081", "http://localhost:8082"}
 
 	// Custom HTTP client per server for increased connection limits
 	httpClients = make([]*http.Client, len(servers))
+
+	// One rate limiter per server so a slow alpha only throttles its own
+	// worker fan-out instead of stalling the whole run.
+	rateLimiters = make([]RateLimiter, len(servers))
 )
 
+// newRateLimiter builds the configured RateLimiter implementation for a
+// single server.
+func newRateLimiter() RateLimiter {
+	switch *rateLimiterKind {
+	case "token-bucket":
+		return NewTokenBucketLimiter(*targetOpsPerSec)
+	case "aimd":
+		return NewAIMDLimiter(*targetOpsPerSec, 1, 1000)
+	default:
+		panic(fmt.Sprintf("unknown rate limiter %q", *rateLimiterKind))
+	}
+}
+
 // buildHTTPClient creates a custom HTTP client with sensible defaults for load testing.
 // This addresses the default Go HTTP client's limited connection pool (MaxIdleConnsPerHost = 2).
 func buildHTTPClient() *http.Client {
@@ -53,17 +87,30 @@ func buildHTTPClient() *http.Client {
 }
 
 func main() {
+	flag.Parse()
+
 	// Initialize custom HTTP clients per server for increased connection limits
 	for i := range httpClients {
 		httpClients[i] = buildHTTPClient()
 	}
 
+	// One rate limiter per server, matching the configured strategy.
+	for i := range rateLimiters {
+		rateLimiters[i] = newRateLimiter()
+	}
+
 	// One GraphQL client per server, using the custom HTTP clients.
 	clients := []*graphql.Client{}
 	for i := range servers {
 		clients = append(clients, graphql.NewClient(fmt.Sprintf("%s/graphql", servers[i]), httpClients[i]))
 	}
 
+	writerImpl, err := newWriter(*protocol, servers, httpClients, clients)
+	if err != nil {
+		panic(fmt.Sprintf("failed to build writer: %v", err))
+	}
+	activeWriter = writerImpl
+
 	ctx := context.Background()
 
 	// Ensure schema exists, then reset data and seed people.
@@ -71,7 +118,24 @@ func main() {
 	dropExistingData(servers[0])
 	bootstrapPeople(ctx, clients[0], people)
 
-	insertTime = make(map[int]time.Time, MAX_EVENTS)
+	journal, err = NewJournal(*journalPath)
+	if err != nil {
+		panic(fmt.Sprintf("failed to open journal: %v", err))
+	}
+	defer journal.Close()
+
+	gapLog, err := os.OpenFile("gap-detector.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		panic(err)
+	}
+	defer gapLog.Close()
+	gapDetector = NewGapDetector(servers, *gapGracePeriod, gapLog)
+
+	chaosTargets, err := parseChaosTargets(*chaosContainers, *chaosNodeIDs)
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse chaos targets: %v", err))
+	}
+	chaosInjector = NewChaosInjector(chaosTargets, parseChaosModes(*chaosModes), *chaosZero, *chaosPartitionScript)
 
 	var wg sync.WaitGroup
 
@@ -85,9 +149,10 @@ func main() {
 	wg.Add(1)
 	go runReporting(ctx, &wg)
 
-	// Expose Prometheus-style metrics for external monitoring
+	// Expose Prometheus metrics for external monitoring, scrapeable
+	// alongside Dgraph's own alpha metrics.
 	go func() {
-		if err := http.ListenAndServe(":3020", http.HandlerFunc(metrics)); err != nil {
+		if err := http.ListenAndServe(":3020", promhttp.Handler()); err != nil {
 			fmt.Printf("metrics server error: %v\n", err)
 		}
 	}()
@@ -96,6 +161,14 @@ func main() {
 	wg.Add(1)
 	go runValidationScheduler(ctx, &wg, clients)
 
+	// Stream a small poll window alongside writes so gaps are caught within
+	// seconds instead of at the next paginated validation checkpoint.
+	go gapDetector.Run(ctx, clients, servers, *gapPollInterval)
+
+	// Inject chaos against target alphas, if configured, to reproduce
+	// raft-related write-loss scenarios.
+	go chaosInjector.Run(ctx, *chaosInterval, *chaosDuration)
+
 	wg.Wait()
 
 	// Final validation pass once all writers stop.
@@ -166,22 +239,20 @@ func runValidationScheduler(ctx context.Context, wg *sync.WaitGroup, clients []*
 func runEventSimulator(ctx context.Context, wg *sync.WaitGroup, clients []*graphql.Client, id int) {
 	defer wg.Done()
 
-	ticker := time.NewTicker(WORKER_LOOP_INTERVAL)
-	defer ticker.Stop()
-
 	errLog, err := os.OpenFile(fmt.Sprintf("error-%d.log", id), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		panic(err)
 	}
 	defer errLog.Close()
 
-	// Write events on a fixed cadence until MAX_EVENTS is reached.
+	// Write events until MAX_EVENTS is reached, paced per-server by
+	// rateLimiters rather than a fixed worker cadence.
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			if eventValue := addEvent(ctx, clients, errLog); eventValue >= MAX_EVENTS {
+		default:
+			if eventValue := addEvent(ctx, clients, errLog, id); eventValue >= MAX_EVENTS {
 				return
 			}
 		}
@@ -197,7 +268,7 @@ type AddEventInput struct {
 	Person *PersonRef `json:"person"`
 }
 
-func addEvent(ctx context.Context, clients []*graphql.Client, errLog *os.File) uint64 {
+func addEvent(ctx context.Context, clients []*graphql.Client, errLog *os.File, workerID int) uint64 {
 	// Reserve the next event value.
 	mu.Lock()
 	value := count
@@ -205,76 +276,70 @@ func addEvent(ctx context.Context, clients []*graphql.Client, errLog *os.File) u
 	mu.Unlock()
 
 	personID := people[randInt(len(people))]
-	event := AddEventInput{
-		Value: &value,
-		Person: &PersonRef{
-			ID: &personID,
-		},
-	}
-
-	var mutation struct {
-		AddEvent struct {
-			Event struct {
-				Value uint64 `graphql:"value"`
-			} `graphql:"event"`
-		} `graphql:"addEvent(input: $input)"`
-	}
-
-	variables := map[string]interface{}{
-		"input": []AddEventInput{event},
-	}
 
 	// Randomize target server to spread writes across alphas.
-	client := clients[randInt(len(clients))]
+	serverIdx := randInt(len(clients))
+	limiter := rateLimiters[serverIdx]
+	server := servers[serverIdx]
 
-	// Track insert time for debugging gaps.
-	insertTimeMu.Lock()
-	insertTime[int(value)] = time.Now()
-	insertTimeMu.Unlock()
+	// Respect this server's rate limit before issuing the write so a slow
+	// alpha only throttles its own worker fan-out.
+	limiter.Wait(ctx)
 
-	result, err := client.MutateRaw(ctx, &mutation, variables)
-	if err != nil {
-		if _, err := errLog.WriteString(fmt.Sprintf("%s: error adding event: %v\n", time.Now().Format(time.RFC3339), err)); err != nil {
-			fmt.Printf("error writing to error log: %v\n", err)
-		}
-	}
+	workerLabel := fmt.Sprintf("%d", workerID)
+	eventWriteInflight.WithLabelValues(workerLabel).Inc()
+	defer eventWriteInflight.WithLabelValues(workerLabel).Dec()
 
-	var response struct {
-		AddEvent struct {
-			Event []struct {
-				Value uint64 `json:"value"`
-			} `json:"event"`
-		} `json:"addEvent"`
-	}
+	submittedAt := time.Now()
 
-	if err := json.Unmarshal(result, &response); err != nil {
-		if _, err := errLog.WriteString(fmt.Sprintf("%s: error unmarshaling add event response: %v\n", time.Now().Format(time.RFC3339), err)); err != nil {
-			fmt.Printf("error writing to error log: %v\n", err)
-		}
-	}
+	respValue, latency, err := activeWriter.Write(ctx, serverIdx, value, personID)
+	eventWriteDuration.WithLabelValues(server, *protocol).Observe(latency.Seconds())
 
-	if len(response.AddEvent.Event) != 1 {
-		msg := fmt.Sprintf("%s: failed to add event (%d) response was %s\n", time.Now().Format(time.RFC3339), value, string(result))
+	status := "success"
+	if err != nil {
+		limiter.Report(classifyError(err))
+		status = "write_error"
+		eventWriteErrors.WithLabelValues(server, errorClass(err)).Inc()
+		msg := fmt.Sprintf("%s: error adding event (%d): %v\n", time.Now().Format(time.RFC3339), value, err)
 		if _, err := errLog.WriteString(msg); err != nil {
-			fmt.Print(msg)
+			fmt.Printf("error writing to error log: %v\n", err)
 		}
+		journal.Append(JournalEntry{Value: value, WorkerID: workerID, Server: server, SubmittedAt: submittedAt, RespondedAt: time.Now(), Status: status, ChaosState: chaosInjector.CurrentState()})
 		return value
 	}
+	limiter.Report(OutcomeSuccess)
 
-	if response.AddEvent.Event[0].Value != value {
-		bytes, err := json.Marshal(event)
-		if err != nil {
-			fmt.Printf("error marshaling event for logging: %v\n", err)
-		}
-		if _, err := errLog.WriteString(fmt.Sprintf("%s: event value mismatch: expected %d, got %d\n\n==Input==\n\n%s\n==Input End ==\n==Response==\n\n%s\n==Response End==\n", time.Now().Format(time.RFC3339), value, response.AddEvent.Event[0].Value, string(bytes), string(result))); err != nil {
+	if respValue != value {
+		status = "value_mismatch"
+		eventWriteErrors.WithLabelValues(server, "value_mismatch").Inc()
+		if _, err := errLog.WriteString(fmt.Sprintf("%s: event value mismatch: expected %d, got %d\n", time.Now().Format(time.RFC3339), value, respValue)); err != nil {
 			fmt.Printf("error writing to error log: %v\n", err)
 		}
-		panic(fmt.Sprintf("event value mismatch: expected %d, got %d. Write payload was %v", value, response.AddEvent.Event[0].Value, string(bytes)))
+		journal.Append(JournalEntry{Value: value, WorkerID: workerID, Server: server, SubmittedAt: submittedAt, RespondedAt: time.Now(), Status: status, ChaosState: chaosInjector.CurrentState()})
+		panic(fmt.Sprintf("event value mismatch: expected %d, got %d", value, respValue))
 	}
 
+	journal.Append(JournalEntry{Value: value, WorkerID: workerID, Server: server, SubmittedAt: submittedAt, RespondedAt: time.Now(), Status: status, ChaosState: chaosInjector.CurrentState()})
+	gapDetector.Expect(server, value, submittedAt)
+
 	return value
 }
 
+// classifyError maps a GraphQL mutation error to an outcome an adaptive rate
+// limiter can react to. The underlying client surfaces transport and GraphQL
+// errors as plain errors, so classification is done on their text.
+func classifyError(err error) RequestOutcome {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "context deadline exceeded"), strings.Contains(msg, "timeout"):
+		return OutcomeTimeout
+	case strings.Contains(msg, "429"), strings.Contains(msg, "Too Many Requests"):
+		return OutcomeHTTPTooManyRequests
+	default:
+		return OutcomeGraphQLError
+	}
+}
+
 func randInt(max int) int {
 	n, err := rand.Int(rand.Reader, big.NewInt(int64(max)))
 	if err != nil {
@@ -397,6 +462,8 @@ func validate(ctx context.Context, clients []*graphql.Client, final bool) {
 
 	// Validate each server independently to detect gaps or duplicates.
 	for i, client := range clients {
+		validationStart := time.Now()
+
 		highWaterMark, err := queryAggregateCount(ctx, client)
 		if err != nil {
 			fmt.Printf("failed to query aggregate count: %v\n", err)
@@ -459,18 +526,20 @@ func validate(ctx context.Context, clients []*graphql.Client, final bool) {
 			}
 		}
 
-		// Report missing values with their insert timestamps for debugging.
+		// Report missing values; cross-reference the write journal against
+		// the exported Dgraph JSON (via the checker's journal subcommand)
+		// for full provenance on where and when each one was written.
 		for value, ok := range seen {
 			if !ok {
-				insertTimeMu.Lock()
-				insertedAt := insertTime[value]
-				insertTimeMu.Unlock()
-				fmt.Printf("missing event value %d (inserted at %s)\n", value, insertedAt.Format(time.RFC3339))
+				fmt.Printf("missing event value %d\n", value)
 				missed++
 				continue
 			}
 		}
 
+		validationMissingEvents.WithLabelValues(servers[i]).Set(float64(missed))
+		validationDuration.WithLabelValues(servers[i]).Observe(time.Since(validationStart).Seconds())
+
 		if countSeen != highWaterMark {
 			fmt.Printf("event count mismatch: aggregate=%d seen=%d outOfRange=%d missed=%d max=%d\n", highWaterMark, countSeen, outOfRange, missed, max)
 			continue
@@ -481,10 +550,6 @@ func validate(ctx context.Context, clients []*graphql.Client, final bool) {
 			passFail = "failed"
 		}
 
-		missingCountMu.Lock()
-		missingCount[servers[i]] = missed
-		missingCountMu.Unlock()
-
 		fmt.Printf("validation %s: aggregate=%d seen=%d outOfRange=%d missed=%d max=%d\n", passFail, highWaterMark, countSeen, outOfRange, missed, max)
 	}
 }
@@ -567,36 +632,3 @@ func dropExistingData(server string) {
 	fmt.Println("dropped existing data successfully")
 }
 
-func metrics(res http.ResponseWriter, req *http.Request) {
-	// Simple text-based metrics endpoint for Prometheus scraping.
-	mu.Lock()
-	dbCount := count
-	mu.Unlock()
-
-	metricsHasInverseRelations := true
-	metricsVersion := "v25.2.0"
-	metricsSchema := "simple-with-an-inverse-relation"
-
-	hasInverse := "false"
-	if metricsHasInverseRelations {
-		hasInverse = "true"
-	}
-
-	for server, missed := range missingCount {
-		_, err := res.Write([]byte(fmt.Sprintf(`# HELP events_missing Number of events missing per server
-# TYPE events_missing gauge
-events_missing{server="%s"} %d
-`, server, missed)))
-		if err != nil {
-			fmt.Printf("error writing metrics response: %v\n", err)
-			return
-		}
-	}
-
-	if _, err := res.Write([]byte(fmt.Sprintf(`# HELP events_total Number of events written
-# TYPE events_total counter
-events_total{version="%s",hasInverseRelations="%s",schema="%s"} %d`, metricsVersion, hasInverse, metricsSchema, dbCount))); err != nil {
-		fmt.Printf("error writing metrics response: %v\n", err)
-		return
-	}
-}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	eventWriteDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "event_write_duration_seconds",
+		Help:    "Latency of event writes, labeled by server and protocol.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"server", "protocol"})
+
+	eventWriteErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "event_write_errors_total",
+		Help: "Count of event write errors, labeled by server and error class.",
+	}, []string{"server", "class"})
+
+	eventWriteInflight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "event_write_inflight",
+		Help: "Number of in-flight event writes, per worker.",
+	}, []string{"worker"})
+
+	validationMissingEvents = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "validation_missing_events",
+		Help: "Number of missing events found in the last validation pass, per server.",
+	}, []string{"server"})
+
+	validationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "validation_duration_seconds",
+		Help:    "Duration of a validation pass, per server.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"server"})
+
+	gapDetectedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gap_detected_total",
+		Help: "Count of gaps flagged by the sliding-window gap detector, per server.",
+	}, []string{"server"})
+
+	chaosActive = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "chaos_active",
+		Help: "Whether a chaos action is currently active (1) or not (0), labeled by target container and mode.",
+	}, []string{"container", "mode"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		eventWriteDuration,
+		eventWriteErrors,
+		eventWriteInflight,
+		validationMissingEvents,
+		validationDuration,
+		gapDetectedTotal,
+		chaosActive,
+	)
+}
+
+// errorClass maps a write error to one of the event_write_errors_total label
+// classes: timeout, 5xx, or graphql_error. Value mismatches are classified
+// separately by the caller since they aren't transport/response errors.
+func errorClass(err error) string {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "context deadline exceeded"), strings.Contains(msg, "timeout"):
+		return "timeout"
+	case strings.Contains(msg, "returned 5"):
+		return "5xx"
+	default:
+		return "graphql_error"
+	}
+}
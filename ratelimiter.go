@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RequestOutcome classifies the result of a single write so that adaptive
+// limiters can react to it.
+type RequestOutcome int
+
+const (
+	OutcomeSuccess RequestOutcome = iota
+	OutcomeGraphQLError
+	OutcomeHTTPTooManyRequests
+	OutcomeTimeout
+)
+
+// RateLimiter paces outbound writes to a single server. Implementations are
+// per-server so a slow alpha only throttles its own worker fan-out instead of
+// the whole run.
+type RateLimiter interface {
+	// Wait blocks until the caller is permitted to issue the next request.
+	Wait(ctx context.Context)
+	// Report records the outcome of a request so adaptive limiters can
+	// adjust their rate.
+	Report(outcome RequestOutcome)
+}
+
+// TokenBucketLimiter issues tokens at a fixed rate, configurable via the
+// -target-ops flag. It does not react to errors; use AIMDLimiter for that.
+type TokenBucketLimiter struct {
+	mu         sync.Mutex
+	tokens     float64
+	maxTokens  float64
+	refillRate float64 // tokens per second
+	lastRefill time.Time
+}
+
+// NewTokenBucketLimiter creates a limiter that allows opsPerSecond sustained
+// throughput with a small burst allowance.
+func NewTokenBucketLimiter(opsPerSecond float64) *TokenBucketLimiter {
+	return &TokenBucketLimiter{
+		tokens:     opsPerSecond,
+		maxTokens:  opsPerSecond,
+		refillRate: opsPerSecond,
+		lastRefill: time.Now(),
+	}
+}
+
+func (l *TokenBucketLimiter) Wait(ctx context.Context) {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		elapsed := now.Sub(l.lastRefill).Seconds()
+		l.lastRefill = now
+		l.tokens += elapsed * l.refillRate
+		if l.tokens > l.maxTokens {
+			l.tokens = l.maxTokens
+		}
+
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return
+		}
+
+		// Not enough tokens yet; work out how long until one is available.
+		wait := time.Duration((1 - l.tokens) / l.refillRate * float64(time.Second))
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (l *TokenBucketLimiter) Report(outcome RequestOutcome) {
+	// Fixed-rate limiter: errors don't change the configured rate.
+}
+
+// AIMDLimiter is an additive-increase/multiplicative-decrease limiter: the
+// allowed rate climbs slowly on success and collapses sharply on GraphQL
+// errors, HTTP 429s, or timeouts, so a struggling server is backed off from
+// quickly but recovers gradually once it stabilizes.
+type AIMDLimiter struct {
+	mu           sync.Mutex
+	rate         float64 // current allowed ops/sec
+	minRate      float64
+	maxRate      float64
+	increaseStep float64
+	decreaseMul  float64
+	lastRequest  time.Time
+}
+
+// NewAIMDLimiter creates an adaptive limiter starting at startRate ops/sec,
+// bounded to [minRate, maxRate].
+func NewAIMDLimiter(startRate, minRate, maxRate float64) *AIMDLimiter {
+	return &AIMDLimiter{
+		rate:         startRate,
+		minRate:      minRate,
+		maxRate:      maxRate,
+		increaseStep: 1, // +1 op/sec per success
+		decreaseMul:  0.5,
+		lastRequest:  time.Now(),
+	}
+}
+
+func (l *AIMDLimiter) Wait(ctx context.Context) {
+	l.mu.Lock()
+	now := time.Now()
+	if l.lastRequest.Before(now) {
+		// The virtual schedule fell behind wall-clock time, e.g. because
+		// callers were all blocked on a slow or paused server. Snap it
+		// forward so the idle gap isn't replayed as an unthrottled burst
+		// once requests resume.
+		l.lastRequest = now
+	}
+	interval := time.Duration(float64(time.Second) / l.rate)
+	next := l.lastRequest.Add(interval)
+	l.lastRequest = next
+	l.mu.Unlock()
+
+	wait := time.Until(next)
+	if wait <= 0 {
+		return
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(wait):
+	}
+}
+
+func (l *AIMDLimiter) Report(outcome RequestOutcome) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch outcome {
+	case OutcomeSuccess:
+		l.rate += l.increaseStep
+	case OutcomeGraphQLError, OutcomeHTTPTooManyRequests, OutcomeTimeout:
+		l.rate *= l.decreaseMul
+	}
+
+	if l.rate < l.minRate {
+		l.rate = l.minRate
+	}
+	if l.rate > l.maxRate {
+		l.rate = l.maxRate
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"time"
 )
 
 type eventObject struct {
@@ -14,15 +15,41 @@ type eventObject struct {
 	EventValue *int `json:"Event.value"`
 }
 
+// journalEntry mirrors the writer's JournalEntry format (see journal.go in
+// the writer program); it's redeclared here since the checker is a separate
+// binary.
+type journalEntry struct {
+	Value       uint64    `json:"value"`
+	WorkerID    int       `json:"worker_id"`
+	Server      string    `json:"server"`
+	SubmittedAt time.Time `json:"submitted_at"`
+	RespondedAt time.Time `json:"responded_at"`
+	Status      string    `json:"status"`
+	ChaosState  string    `json:"chaos_state,omitempty"`
+}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "journal" {
+		runJournalCmd(os.Args[2:])
+		return
+	}
+
+	runScanCmd(os.Args[1:])
+}
+
+// runScanCmd is the original top-of-chunk JSON scanner: it reports which
+// event values are missing from an exported Dgraph JSON dump.
+func runScanCmd(args []string) {
+	fs := flag.NewFlagSet("scan", flag.ExitOnError)
+
 	var filePath string
 	var maxValue int
 	var printMissing bool
 
-	flag.StringVar(&filePath, "file", "", "Path to the JSON file to scan")
-	flag.IntVar(&maxValue, "max", 100005, "Maximum event value (exclusive) to check for missing values")
-	flag.BoolVar(&printMissing, "print-missing", true, "Print each missing value")
-	flag.Parse()
+	fs.StringVar(&filePath, "file", "", "Path to the JSON file to scan")
+	fs.IntVar(&maxValue, "max", 100005, "Maximum event value (exclusive) to check for missing values")
+	fs.BoolVar(&printMissing, "print-missing", true, "Print each missing value")
+	fs.Parse(args)
 
 	if filePath == "" {
 		fmt.Fprintln(os.Stderr, "error: -file is required")
@@ -33,26 +60,106 @@ func main() {
 		os.Exit(1)
 	}
 
-	file, err := os.Open(filePath)
+	seen, totalValues, err := scanExportedValues(filePath, maxValue)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	missing := 0
+	for i, ok := range seen {
+		if !ok {
+			missing++
+			if printMissing {
+				fmt.Println(i)
+			}
+		}
+	}
+
+	fmt.Printf("checked=0..%d seen=%d missing=%d\n", maxValue-1, totalValues, missing)
+}
+
+// runJournalCmd cross-references the write journal against an exported
+// Dgraph JSON dump: for every value missing from the export it reports
+// which server it was written to, how long ago, and whether the mutation
+// returned success, so a crashed or gappy run stays debuggable.
+func runJournalCmd(args []string) {
+	fs := flag.NewFlagSet("journal", flag.ExitOnError)
+
+	var filePath string
+	var journalPath string
+	var maxValue int
+
+	fs.StringVar(&filePath, "file", "", "Path to the exported Dgraph JSON file")
+	fs.StringVar(&journalPath, "journal", "", "Path to the writer's journal file")
+	fs.IntVar(&maxValue, "max", 100005, "Maximum event value (exclusive) to check for missing values")
+	fs.Parse(args)
+
+	if filePath == "" {
+		fmt.Fprintln(os.Stderr, "error: -file is required")
+		os.Exit(1)
+	}
+	if journalPath == "" {
+		fmt.Fprintln(os.Stderr, "error: -journal is required")
+		os.Exit(1)
+	}
+	if maxValue <= 0 {
+		fmt.Fprintln(os.Stderr, "error: -max must be > 0")
+		os.Exit(1)
+	}
+
+	seen, _, err := scanExportedValues(filePath, maxValue)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "error: open file: %v\n", err)
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
+
+	entries, err := readJournal(journalPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: read journal: %v\n", err)
+		os.Exit(1)
+	}
+
+	now := time.Now()
+	missing := 0
+	for value, ok := range seen {
+		if ok {
+			continue
+		}
+		missing++
+
+		entry, found := entries[uint64(value)]
+		if !found {
+			fmt.Printf("missing value %d: no journal entry found\n", value)
+			continue
+		}
+
+		fmt.Printf("missing value %d: server=%s written=%s ago status=%s\n", value, entry.Server, now.Sub(entry.RespondedAt).Round(time.Second), entry.Status)
+	}
+
+	fmt.Printf("checked=0..%d missing=%d\n", maxValue-1, missing)
+}
+
+// scanExportedValues streams the top-level array of an exported Dgraph JSON
+// dump so large files don't need to fit in memory, returning which event
+// values in [0, maxValue) were present.
+func scanExportedValues(filePath string, maxValue int) ([]bool, int, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("open file: %w", err)
+	}
 	defer file.Close()
 
 	reader := bufio.NewReader(file)
 	decoder := json.NewDecoder(reader)
 
-	// Stream the top-level array so large files don't need to fit in memory.
 	tok, err := decoder.Token()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "error: read JSON start token: %v\n", err)
-		os.Exit(1)
+		return nil, 0, fmt.Errorf("read JSON start token: %w", err)
 	}
 	delim, ok := tok.(json.Delim)
 	if !ok || delim != '[' {
-		fmt.Fprintln(os.Stderr, "error: expected JSON array at top level")
-		os.Exit(1)
+		return nil, 0, fmt.Errorf("expected JSON array at top level")
 	}
 
 	// seen[i] == true means Event.value == i was present in the file.
@@ -62,8 +169,7 @@ func main() {
 	for decoder.More() {
 		var obj eventObject
 		if err := decoder.Decode(&obj); err != nil {
-			fmt.Fprintf(os.Stderr, "error: decode object: %v\n", err)
-			os.Exit(1)
+			return nil, 0, fmt.Errorf("decode object: %w", err)
 		}
 
 		if obj.EventValue != nil {
@@ -80,20 +186,47 @@ func main() {
 	// Consume the closing array token if present.
 	if _, err := decoder.Token(); err != nil {
 		if err != io.EOF {
-			fmt.Fprintf(os.Stderr, "error: read JSON end token: %v\n", err)
-			os.Exit(1)
+			return nil, 0, fmt.Errorf("read JSON end token: %w", err)
 		}
 	}
 
-	missing := 0
-	for i, ok := range seen {
-		if !ok {
-			missing++
-			if printMissing {
-				fmt.Println(i)
-			}
+	return seen, totalValues, nil
+}
+
+// readJournal loads the writer's append-only journal, keyed by event value.
+// If a value appears more than once (e.g. a future retrying writer), the
+// last entry wins.
+func readJournal(path string) (map[uint64]journalEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := make(map[uint64]journalEntry)
+
+	scanner := bufio.NewScanner(file)
+	// Journal lines are small JSON objects, but allow generous headroom.
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var entry journalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: skipping malformed journal line: %v\n", err)
+			continue
 		}
+
+		entries[entry.Value] = entry
 	}
 
-	fmt.Printf("checked=0..%d seen=%d missing=%d\n", maxValue-1, totalValues, missing)
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
 }
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/dgraph-io/dgo/v230"
+	"github.com/dgraph-io/dgo/v230/protos/api"
+	"github.com/hasura/go-graphql-client"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Writer submits a single event write to one server. Implementations exist
+// per write protocol (GraphQL, DQL, gRPC) so a load test can tell whether
+// observed gaps are specific to Dgraph's GraphQL layer or reproduce deeper
+// down the stack.
+type Writer interface {
+	// Write submits an event write for value/personID to the server at
+	// serverIdx, returning the value the server acknowledges (so the caller
+	// can verify it round-tripped) and how long the request took.
+	Write(ctx context.Context, serverIdx int, value uint64, personID string) (respValue uint64, latency time.Duration, err error)
+}
+
+// newWriter builds the Writer for the configured -protocol flag.
+func newWriter(protocol string, servers []string, httpClients []*http.Client, graphqlClients []*graphql.Client) (Writer, error) {
+	switch protocol {
+	case "graphql":
+		return &GraphQLWriter{clients: graphqlClients}, nil
+	case "dql":
+		return &DQLWriter{servers: servers, httpClients: httpClients}, nil
+	case "grpc":
+		return NewGRPCWriter(servers)
+	default:
+		return nil, fmt.Errorf("unknown protocol %q", protocol)
+	}
+}
+
+// GraphQLWriter writes events via the addEvent GraphQL mutation. This is the
+// original write path.
+type GraphQLWriter struct {
+	clients []*graphql.Client
+}
+
+func (w *GraphQLWriter) Write(ctx context.Context, serverIdx int, value uint64, personID string) (uint64, time.Duration, error) {
+	input := AddEventInput{
+		Value: &value,
+		Person: &PersonRef{
+			ID: &personID,
+		},
+	}
+
+	var mutation struct {
+		AddEvent struct {
+			Event struct {
+				Value uint64 `graphql:"value"`
+			} `graphql:"event"`
+		} `graphql:"addEvent(input: $input)"`
+	}
+
+	variables := map[string]interface{}{
+		"input": []AddEventInput{input},
+	}
+
+	start := time.Now()
+	result, err := w.clients[serverIdx].MutateRaw(ctx, &mutation, variables)
+	latency := time.Since(start)
+	if err != nil {
+		return 0, latency, err
+	}
+
+	var response struct {
+		AddEvent struct {
+			Event []struct {
+				Value uint64 `json:"value"`
+			} `json:"event"`
+		} `json:"addEvent"`
+	}
+
+	if err := json.Unmarshal(result, &response); err != nil {
+		return 0, latency, fmt.Errorf("unmarshal add event response: %w", err)
+	}
+
+	if len(response.AddEvent.Event) != 1 {
+		return 0, latency, fmt.Errorf("failed to add event (%d): response was %s", value, string(result))
+	}
+
+	return response.AddEvent.Event[0].Value, latency, nil
+}
+
+// DQLWriter writes events as a raw DQL mutation via /mutate?commitNow=true,
+// upserting the event's edge to its person by Person.id within the same
+// request.
+type DQLWriter struct {
+	servers     []string
+	httpClients []*http.Client
+}
+
+func (w *DQLWriter) Write(ctx context.Context, serverIdx int, value uint64, personID string) (uint64, time.Duration, error) {
+	body := map[string]interface{}{
+		"query": fmt.Sprintf(`{ v as var(func: eq(Person.id, %q)) }`, personID),
+		"set": []map[string]interface{}{
+			{
+				"uid":          "_:event",
+				"Event.value":  value,
+				"Event.person": map[string]string{"uid": "uid(v)"},
+			},
+		},
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return 0, 0, fmt.Errorf("marshal mutation: %w", err)
+	}
+
+	mutateURL := fmt.Sprintf("%s/mutate?commitNow=true", w.servers[serverIdx])
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, mutateURL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, 0, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := w.httpClients[serverIdx].Do(req)
+	latency := time.Since(start)
+	if err != nil {
+		return 0, latency, fmt.Errorf("mutate request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, latency, fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, latency, fmt.Errorf("mutate request returned %s: %s", resp.Status, string(respBody))
+	}
+
+	var result struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return 0, latency, fmt.Errorf("unmarshal response: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		return 0, latency, fmt.Errorf("mutate error: %s", result.Errors[0].Message)
+	}
+
+	// DQL mutations don't echo the written value back; a 200 with no
+	// "errors" is the server's confirmation that the write committed.
+	return value, latency, nil
+}
+
+// GRPCWriter writes events over Dgraph's gRPC API via dgo, bypassing the
+// GraphQL and HTTP layers entirely.
+type GRPCWriter struct {
+	clients []*dgo.Dgraph
+}
+
+// NewGRPCWriter dials a gRPC connection to each server's alpha.
+func NewGRPCWriter(servers []string) (*GRPCWriter, error) {
+	clients := make([]*dgo.Dgraph, len(servers))
+	for i, server := range servers {
+		addr, err := grpcAddr(server)
+		if err != nil {
+			return nil, fmt.Errorf("derive grpc address for %s: %w", server, err)
+		}
+
+		conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return nil, fmt.Errorf("dial %s: %w", addr, err)
+		}
+
+		clients[i] = dgo.NewDgraphClient(api.NewDgraphClient(conn))
+	}
+
+	return &GRPCWriter{clients: clients}, nil
+}
+
+func (w *GRPCWriter) Write(ctx context.Context, serverIdx int, value uint64, personID string) (uint64, time.Duration, error) {
+	query := fmt.Sprintf(`{ v as var(func: eq(Person.id, %q)) }`, personID)
+	nquads := []byte(fmt.Sprintf(`_:event <Event.value> "%d"^^<xs:int> . _:event <Event.person> uid(v) .`, value))
+
+	start := time.Now()
+	_, err := w.clients[serverIdx].NewTxn().Do(ctx, &api.Request{
+		Query:     query,
+		Mutations: []*api.Mutation{{SetNquads: nquads}},
+		CommitNow: true,
+	})
+	latency := time.Since(start)
+	if err != nil {
+		return 0, latency, fmt.Errorf("grpc mutate: %w", err)
+	}
+
+	return value, latency, nil
+}
+
+// grpcAddr derives a server's internal gRPC address from its external HTTP
+// address, following Dgraph's standard local cluster convention of
+// offsetting the alpha's gRPC port by 1000 from its HTTP port (8080 ->
+// 9080, 8081 -> 9081, ...).
+func grpcAddr(server string) (string, error) {
+	u, err := url.Parse(server)
+	if err != nil {
+		return "", err
+	}
+
+	host, portStr, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		return "", err
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return "", err
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(port+1000)), nil
+}
+